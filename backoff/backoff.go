@@ -0,0 +1,146 @@
+// Package backoff implements exponential backoff with jitter for retrying
+// flaky operations such as joining the bootstrap network or accepting
+// inbound connections, without hammering the peer or blocking shutdown.
+package backoff
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	initialDelay = 5 * time.Millisecond
+	temporaryMax = time.Second
+	permanentMax = 5 * time.Second
+)
+
+// Backoff tracks the delay used between retries of a single logical
+// operation. The zero value is ready to use and starts from initialDelay.
+type Backoff struct {
+	delay time.Duration
+}
+
+// Reset returns the backoff to its starting state after a success.
+func (b *Backoff) Reset() {
+	b.delay = 0
+}
+
+// Next advances the backoff state based on err and returns the jittered
+// delay to wait before the next attempt. Temporary net.Errors are capped at
+// temporaryMax; everything else is treated as permanent and capped at the
+// higher permanentMax.
+func (b *Backoff) Next(err error) time.Duration {
+	if b.delay == 0 {
+		b.delay = initialDelay
+	} else {
+		b.delay *= 2
+	}
+
+	max := permanentMax
+	if ne, ok := err.(net.Error); ok && ne.Temporary() {
+		max = temporaryMax
+	}
+	if b.delay > max {
+		b.delay = max
+	}
+
+	return jitter(b.delay)
+}
+
+// jitter returns a value in [d/2, d] so that many retrying peers don't
+// collide on the same schedule.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half+1)))
+}
+
+// Wait sleeps for d, returning early with ctx.Err() if ctx is cancelled
+// first so callers can shut down without blocking on a pending retry.
+func Wait(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Retry calls fn until it succeeds or ctx is cancelled, backing off between
+// attempts. It drives the Join retry loop; accept-loop middleware uses
+// AcceptTracker, which applies the same Backoff schedule per key.
+func Retry(ctx context.Context, fn func() error) error {
+	var b Backoff
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if werr := Wait(ctx, b.Next(err)); werr != nil {
+			return werr
+		}
+	}
+}
+
+// AcceptTracker applies the same exponential-backoff schedule as Retry to
+// repeatedly rejected entries (keyed however the caller likes, e.g. by
+// remote IP in an accept-loop middleware), so a peer that keeps retrying
+// immediately after a rejection gets an increasing cooldown instead of
+// re-running the full accept check on every attempt. The zero value is not
+// usable; construct with NewAcceptTracker.
+type AcceptTracker struct {
+	mutex sync.Mutex
+	byKey map[string]*acceptEntry
+}
+
+type acceptEntry struct {
+	backoff    Backoff
+	retryAfter time.Time
+}
+
+// NewAcceptTracker creates an empty AcceptTracker.
+func NewAcceptTracker() *AcceptTracker {
+	return &AcceptTracker{byKey: make(map[string]*acceptEntry)}
+}
+
+// Blocked reports whether key is still inside the cooldown from a previous
+// Reject call.
+func (t *AcceptTracker) Blocked(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry, ok := t.byKey[key]
+	return ok && time.Now().Before(entry.retryAfter)
+}
+
+// Reject records a rejection of key due to err and returns the jittered
+// cooldown (computed the same way Retry paces its attempts) before key
+// should be reconsidered.
+func (t *AcceptTracker) Reject(key string, err error) time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	entry, ok := t.byKey[key]
+	if !ok {
+		entry = &acceptEntry{}
+		t.byKey[key] = entry
+	}
+	delay := entry.backoff.Next(err)
+	entry.retryAfter = time.Now().Add(delay)
+	return delay
+}
+
+// Accept clears key's backoff state after a successful attempt.
+func (t *AcceptTracker) Accept(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.byKey, key)
+}
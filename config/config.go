@@ -0,0 +1,43 @@
+// Package config loads the small set of runtime tunables (rate-limit
+// parameters, log level) that a SIGHUP reload applies without restarting
+// the node.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Tunables holds the values that can change across a reload. A zero field
+// means "leave the current setting alone" so a partial config file only
+// overrides what it mentions.
+type Tunables struct {
+	MsgRate   float64 `json:"msg_rate"`
+	MsgBurst  float64 `json:"msg_burst"`
+	ConnRate  float64 `json:"conn_rate"`
+	ConnBurst float64 `json:"conn_burst"`
+	LogLevel  string  `json:"log_level"`
+}
+
+// Load reads Tunables from the JSON file at path. An empty path or a
+// missing file both return the zero value rather than an error, since
+// having no config file just means "use the command-line defaults".
+func Load(path string) (Tunables, error) {
+	var t Tunables
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return t, fmt.Errorf("read config file: %v", err)
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return t, fmt.Errorf("parse config file: %v", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,105 @@
+// Package identity manages this node's persistent Ed25519 keypair.
+//
+// The nnet node ID is derived deterministically from the public key, so a
+// node keeps the same ID across restarts and a peer's identity can be
+// verified with a signature instead of merely trusted on first use.
+package identity
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Identity wraps the Ed25519 keypair used to derive this node's ID and to
+// sign/verify the connection handshake.
+type Identity struct {
+	Public  ed25519.PublicKey
+	Private ed25519.PrivateKey
+}
+
+// Generate creates a brand new Identity backed by a fresh keypair.
+func Generate() (*Identity, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate ed25519 key: %v", err)
+	}
+	return &Identity{Public: pub, Private: priv}, nil
+}
+
+// Load reads an Identity previously written by Save from path.
+func Load(path string) (*Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read node key file: %v", err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("decode node key file: %v", err)
+	}
+	if len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("node key file has %d bytes, want %d", len(raw), ed25519.PrivateKeySize)
+	}
+	priv := ed25519.PrivateKey(raw)
+	pub, ok := priv.Public().(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("derive public key from node key file")
+	}
+	return &Identity{Public: pub, Private: priv}, nil
+}
+
+// LoadOrGenerate loads the identity stored at path if it exists, otherwise
+// generates a new one and saves it to path. If path is empty, a fresh
+// identity is generated and never persisted to disk.
+func LoadOrGenerate(path string) (*Identity, error) {
+	if path == "" {
+		return Generate()
+	}
+	if _, err := os.Stat(path); err == nil {
+		return Load(path)
+	}
+	id, err := Generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := id.Save(path); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// Save writes the hex-encoded private key to path with owner-only
+// permissions.
+func (id *Identity) Save(path string) error {
+	return os.WriteFile(path, []byte(hex.EncodeToString(id.Private)), 0600)
+}
+
+// NodeID returns the nnet node ID derived from the public key. Using the
+// raw public key as the ID makes it both deterministic across restarts and
+// self-certifying: anyone can check a signature against it.
+func (id *Identity) NodeID() []byte {
+	return append([]byte(nil), id.Public...)
+}
+
+// Fingerprint returns the hex-encoded public key, used as the blacklist key
+// so bans survive node restarts.
+func (id *Identity) Fingerprint() string {
+	return hex.EncodeToString(id.Public)
+}
+
+// Sign signs data with the node's private key.
+func (id *Identity) Sign(data []byte) []byte {
+	return ed25519.Sign(id.Private, data)
+}
+
+// Verify reports whether sig is a valid Ed25519 signature over data made by
+// the holder of the private key matching the node ID pub.
+func Verify(pub, data, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(ed25519.PublicKey(pub), data, sig)
+}
@@ -0,0 +1,63 @@
+// Package logging is a small structured-logging façade around log/slog,
+// used in place of scattered fmt.Printf calls so connection events, message
+// receipt, and blacklist operations can be filtered by level and consumed
+// as machine-parseable key/value pairs by an app embedding this PoC as a
+// library.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// LevelTrace sits below slog.LevelDebug so very chatty per-message events
+// can be filtered out even while debugging.
+const LevelTrace = slog.Level(-8)
+
+// ParseLevel maps the -log-level flag values (and config.Tunables.LogLevel)
+// to an slog.Level.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "trace":
+		return LevelTrace, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// Logger wraps an *slog.Logger with a Trace method and a mutable level, so
+// -log-level can be changed by a SIGHUP reload without recreating it.
+type Logger struct {
+	*slog.Logger
+	level *slog.LevelVar
+}
+
+// New creates a Logger that writes JSON lines to w, filtered at level.
+func New(w *os.File, level slog.Level) *Logger {
+	lv := &slog.LevelVar{}
+	lv.Set(level)
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lv})
+	return &Logger{Logger: slog.New(handler), level: lv}
+}
+
+// SetLevel changes the minimum level logged going forward.
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// Trace logs msg at LevelTrace, below slog's own Debug, for events too
+// chatty to want even while debugging (e.g. every relayed message).
+func (l *Logger) Trace(msg string, args ...any) {
+	l.Logger.Log(context.Background(), LevelTrace, msg, args...)
+}
@@ -15,32 +15,59 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/nknorg/nnet"
 	"github.com/nknorg/nnet/node"
 	pbmsg "github.com/nknorg/nnet/protobuf/message"
 	pbnode "github.com/nknorg/nnet/protobuf/node"
+
+	"github.com/harshu4/Nnet-PoC/backoff"
+	"github.com/harshu4/Nnet-PoC/config"
+	"github.com/harshu4/Nnet-PoC/identity"
+	"github.com/harshu4/Nnet-PoC/logging"
+	"github.com/harshu4/Nnet-PoC/ratelimiter"
+	"github.com/harshu4/Nnet-PoC/replay"
 )
 
+// handshakePrefix marks an inbound message as a signed-handshake challenge
+// rather than application data, so BytesReceived can route it away from the
+// CLI's message log.
+const handshakePrefix = "NNETPOC-HANDSHAKE:"
+
+// errAcceptRateLimited is passed to backoff.AcceptTracker.Reject so repeat
+// rate-limit rejections back off like a permanent error (capped at 5s)
+// rather than a transient one.
+var errAcceptRateLimited = errors.New("connection rate limited")
+
 // BlackList maintains a list of blocked node IDs
 type BlackList struct {
 	blockedIDs map[string]bool
 	mutex      sync.RWMutex
+	logger     *logging.Logger
 }
 
-// NewBlackList creates a new blacklist
-func NewBlackList() *BlackList {
+// NewBlackList creates a new blacklist that logs additions/removals through
+// logger.
+func NewBlackList(logger *logging.Logger) *BlackList {
 	return &BlackList{
 		blockedIDs: make(map[string]bool),
+		logger:     logger,
 	}
 }
 
@@ -49,7 +76,7 @@ func (b *BlackList) AddID(id string) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 	b.blockedIDs[id] = true
-	fmt.Printf("Added node ID %s to blacklist\n", id)
+	b.logger.Info("blacklist: added id", "id", id)
 }
 
 // RemoveID removes a node ID from the blacklist
@@ -57,7 +84,7 @@ func (b *BlackList) RemoveID(id string) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 	delete(b.blockedIDs, id)
-	fmt.Printf("Removed node ID %s from blacklist\n", id)
+	b.logger.Info("blacklist: removed id", "id", id)
 }
 
 // IsBlocked checks if a node ID is blacklisted
@@ -67,6 +94,154 @@ func (b *BlackList) IsBlocked(id string) bool {
 	return b.blockedIDs[id]
 }
 
+// Snapshot returns a copy of the currently blocked IDs, used to diff
+// against a freshly reloaded blacklist file.
+func (b *BlackList) Snapshot() map[string]bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	out := make(map[string]bool, len(b.blockedIDs))
+	for id, blocked := range b.blockedIDs {
+		out[id] = blocked
+	}
+	return out
+}
+
+// VerifiedPeers tracks which remote IDs have completed the signed
+// handshake, so BytesReceived can gate application traffic on it when
+// -require-signed is set instead of only disconnecting after the fact.
+type VerifiedPeers struct {
+	mutex    sync.RWMutex
+	verified map[string]bool
+}
+
+// NewVerifiedPeers creates an empty set of handshake-verified peers.
+func NewVerifiedPeers() *VerifiedPeers {
+	return &VerifiedPeers{verified: make(map[string]bool)}
+}
+
+// Mark records id as having passed the signed handshake.
+func (v *VerifiedPeers) Mark(id string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.verified[id] = true
+}
+
+// Verified reports whether id has passed the signed handshake.
+func (v *VerifiedPeers) Verified(id string) bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.verified[id]
+}
+
+// loadBlacklistIDs resolves the -blacklist flag value: if it names an
+// existing file, the IDs are read from it (one per line, or comma
+// separated); otherwise it is treated as an inline comma-separated list.
+func loadBlacklistIDs(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(value); err == nil && !info.IsDir() {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, fmt.Errorf("read blacklist file: %v", err)
+		}
+		return splitIDs(string(data)), nil
+	}
+	return splitIDs(value), nil
+}
+
+func splitIDs(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	})
+	ids := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if id := strings.TrimSpace(field); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// applyBlacklistDiff adds/removes IDs so the in-memory blacklist matches
+// ids, calling closeConn for every newly blocked peer so its open connection
+// (if any) doesn't linger. closeConn is a parameter (rather than calling
+// closeConnectionsFor directly) so the add/remove bookkeeping can be tested
+// without a live *nnet.NNet.
+func applyBlacklistDiff(blacklist *BlackList, ids []string, closeConn func(idHex string)) {
+	want := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		want[id] = true
+	}
+	have := blacklist.Snapshot()
+
+	for id := range want {
+		if !have[id] {
+			blacklist.AddID(id)
+			closeConn(id)
+		}
+	}
+	for id := range have {
+		if !want[id] {
+			blacklist.RemoveID(id)
+		}
+	}
+}
+
+// closeConnectionsFor closes any currently open connection to the peer
+// identified by idHex (hex-encoded node ID).
+func closeConnectionsFor(nn *nnet.NNet, logger *logging.Logger, idHex string) {
+	neighbors, err := nn.GetLocalNode().GetNeighbors(nil)
+	if err != nil {
+		logger.Error("reload: list neighbors failed", "remote_id", idHex, "err", err)
+		return
+	}
+	for _, peer := range neighbors {
+		if hex.EncodeToString(peer.Id) != idHex {
+			continue
+		}
+		peer.Stop(nil)
+		logger.Info("reload: closed connection", "remote_id", idHex)
+	}
+}
+
+// applyTunables overwrites *msgRate etc. with any non-zero value cfg sets,
+// leaving the rest at whatever the caller passed in.
+func applyTunables(cfg config.Tunables, msgRate, msgBurst, connRate, connBurst *float64, logLevel *string) {
+	if cfg.MsgRate > 0 {
+		*msgRate = cfg.MsgRate
+	}
+	if cfg.MsgBurst > 0 {
+		*msgBurst = cfg.MsgBurst
+	}
+	if cfg.ConnRate > 0 {
+		*connRate = cfg.ConnRate
+	}
+	if cfg.ConnBurst > 0 {
+		*connBurst = cfg.ConnBurst
+	}
+	if cfg.LogLevel != "" {
+		*logLevel = cfg.LogLevel
+	}
+}
+
+// seqPrefixed prepends the next replay-protection sequence number to
+// payload when enabled is true, matching what the BytesReceived middleware
+// expects to find at the front of a relayed message.
+func seqPrefixed(seqCounter *replay.Counter, enabled bool, payload []byte) ([]byte, error) {
+	if !enabled {
+		return payload, nil
+	}
+	seq, err := seqCounter.Next()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint64(buf[:8], seq)
+	copy(buf[8:], payload)
+	return buf, nil
+}
+
 func generateRandomID(length int) ([]byte, error) {
 	id := make([]byte, length)
 	_, err := rand.Read(id)
@@ -86,46 +261,250 @@ func printHelp() {
 	fmt.Println("  broadcast <message>           - Broadcast message to all peers")
 	fmt.Println("  blacklist <node_id>           - Add a node to blacklist")
 	fmt.Println("  unblacklist <node_id>         - Remove a node from blacklist")
+	fmt.Println("  stats                         - Show rate-limiter drop counts")
+	fmt.Println("  reload                        - Re-read the blacklist and config files")
 }
 
 func main() {
 	// Parse command line flags
 	bootstrapAddr := flag.String("seed", "", "Seed node address to join (empty for bootstrap node)")
 	port := flag.Int("port", 30001, "Local port to listen on")
-	nodeID := flag.String("id", "", "Node identifier (optional, random if not specified)")
-	blacklistIDs := flag.String("blacklist", "", "Comma-separated node IDs to blacklist (hex encoded)")
+	blacklistIDs := flag.String("blacklist", "", "Comma-separated node IDs to blacklist (hex encoded), or a path to a file containing them")
+	configFile := flag.String("config", "", "Path to a JSON file with reloadable tunables (rate limits, log level)")
+	nodeKeyFile := flag.String("nodekey", "", "Path to this node's Ed25519 key file (created automatically if missing)")
+	genKey := flag.Bool("genkey", false, "Generate a new node key, write it to -nodekey, and exit")
+	requireSigned := flag.Bool("require-signed", false, "Reject peers that fail the signed handshake")
+	msgRate := flag.Float64("msg-rate", 20, "Inbound messages allowed per second, per peer")
+	msgBurst := flag.Float64("msg-burst", 40, "Inbound message burst allowance, per peer")
+	connRate := flag.Float64("conn-rate", 5, "Inbound connection attempts allowed per second, per remote IP")
+	connBurst := flag.Float64("conn-burst", 10, "Inbound connection attempt burst allowance, per remote IP")
+	replayWindowEnabled := flag.Bool("replay-window", true, "Reject relayed messages outside the replay-protection window")
+	logLevelFlag := flag.String("log-level", "info", "Log level for structured logs: trace, debug, info, warn, error")
+	logFileFlag := flag.String("log-file", "", "Write structured logs here instead of stderr")
 	flag.Parse()
 
-	// Initialize blacklist
-	blacklist := NewBlackList()
-	if *blacklistIDs != "" {
-		ids := strings.Split(*blacklistIDs, ",")
-		for _, id := range ids {
-			blacklist.AddID(strings.TrimSpace(id))
+	initialLevel, err := logging.ParseLevel(*logLevelFlag)
+	if err != nil {
+		log.Fatalf("Invalid -log-level: %v", err)
+	}
+	logOut := os.Stderr
+	if *logFileFlag != "" {
+		f, err := os.OpenFile(*logFileFlag, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("Open -log-file error: %v", err)
+		}
+		defer f.Close()
+		logOut = f
+	}
+	logger := logging.New(logOut, initialLevel)
+
+	if *genKey {
+		if *nodeKeyFile == "" {
+			log.Fatalf("-genkey requires -nodekey <path>")
 		}
+		ident, err := identity.Generate()
+		if err != nil {
+			log.Fatalf("Generate node key error: %v", err)
+		}
+		if err := ident.Save(*nodeKeyFile); err != nil {
+			log.Fatalf("Save node key error: %v", err)
+		}
+		fmt.Printf("Wrote new node key to %s (id: %s)\n", *nodeKeyFile, ident.Fingerprint())
+		return
 	}
 
-	// Create node info
-	var id []byte
-	var err error
+	// Load (or create) this node's persistent identity
+	ident, err := identity.LoadOrGenerate(*nodeKeyFile)
+	if err != nil {
+		log.Fatalf("Load node key error: %v", err)
+	}
+	if *nodeKeyFile == "" {
+		logger.Warn("no -nodekey given, using an ephemeral identity that will not survive a restart")
+	}
+
+	// The outbound sequence counter is persisted alongside the node key so
+	// a restart never reissues a sequence number a peer already saw
+	seqCounterPath := ""
+	if *nodeKeyFile != "" {
+		seqCounterPath = *nodeKeyFile + ".seq"
+	}
+	seqCounter, err := replay.LoadCounter(seqCounterPath)
+	if err != nil {
+		log.Fatalf("Load replay counter error: %v", err)
+	}
+	replayWindow := replay.NewWindow()
+
+	// verifiedPeers gates BytesReceived when -require-signed is set, so a
+	// peer's messages aren't processed until its RemoteNodeReady challenge
+	// has succeeded.
+	verifiedPeers := NewVerifiedPeers()
+
+	// Initialize blacklist, keyed off the remote's public-key fingerprint so
+	// bans carry over across restarts of either side. If -blacklist names a
+	// file, remember its path so SIGHUP/"reload" can re-read it.
+	blacklist := NewBlackList(logger)
+	blacklistPath := ""
+	if info, err := os.Stat(*blacklistIDs); *blacklistIDs != "" && err == nil && !info.IsDir() {
+		blacklistPath = *blacklistIDs
+	}
+	initialIDs, err := loadBlacklistIDs(*blacklistIDs)
+	if err != nil {
+		log.Fatalf("Load blacklist error: %v", err)
+	}
+	for _, id := range initialIDs {
+		blacklist.AddID(id)
+	}
+
+	// Tunables that a SIGHUP (or the "reload" command) can change without a
+	// restart: rate-limit parameters and the log level
+	msgRateVal, msgBurstVal := *msgRate, *msgBurst
+	connRateVal, connBurstVal := *connRate, *connBurst
+	var logLevel atomic.Value
+	logLevel.Store(*logLevelFlag)
+	if cfg, err := config.Load(*configFile); err != nil {
+		log.Fatalf("Load config error: %v", err)
+	} else {
+		level := logLevel.Load().(string)
+		applyTunables(cfg, &msgRateVal, &msgBurstVal, &connRateVal, &connBurstVal, &level)
+		logLevel.Store(level)
+		if lvl, err := logging.ParseLevel(level); err == nil {
+			logger.SetLevel(lvl)
+		}
+	}
+
+	// runCtx is cancelled when the user runs "exit", so background retries
+	// and rate-limiter GC don't outlive the node
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	// Rate limiters: one token bucket per peer ID for messages, one per
+	// remote IP for outbound connection attempts (WillConnectToNode), and a
+	// separate one per remote IP for accepted inbound connections
+	// (ConnectionAccepted) so a misbehaving relay peer can't flood this node
+	// or burn handshake CPU. acceptConnLimiter is kept distinct from
+	// connLimiter because both middlewares can fire for the same remote
+	// host (e.g. this node dialing back a peer that just connected to it);
+	// sharing one bucket between them would silently halve the throughput
+	// -conn-rate/-conn-burst imply.
+	msgLimiter := ratelimiter.New(msgRateVal, msgBurstVal)
+	connLimiter := ratelimiter.New(connRateVal, connBurstVal)
+	acceptConnLimiter := ratelimiter.New(connRateVal, connBurstVal)
+	go msgLimiter.RunGC(runCtx, time.Minute, 10*time.Minute)
+	go connLimiter.RunGC(runCtx, time.Minute, 10*time.Minute)
+	go acceptConnLimiter.RunGC(runCtx, time.Minute, 10*time.Minute)
 
 	// Create nnet config with custom port
 	conf := &nnet.Config{
 		Port:      uint16(*port),
 		Transport: "tcp",
 	}
-	var nn *nnet.NNet
-	// Create nnet instance
-	if len(*nodeID) == 0 {
-		nn, err = nnet.NewNNet(nil, conf)
-	} else {
-		id, _ = hex.DecodeString(*nodeID)
-		nn, err = nnet.NewNNet(id, conf)
-	}
+	nn, err := nnet.NewNNet(ident.NodeID(), conf)
 	if err != nil {
 		log.Fatalf("Create nnet error: %v", err)
 	}
 
+	// reload re-reads the blacklist file and the config file, applying the
+	// delta and closing connections to newly blocked peers. It backs both
+	// the SIGHUP handler and the "reload" CLI command. reloadMu serializes
+	// the two, since each reload starts from the rates the previous one set.
+	var reloadMu sync.Mutex
+	reload := func() {
+		reloadMu.Lock()
+		defer reloadMu.Unlock()
+		logger.Info("reload: re-reading blacklist and config")
+
+		if blacklistPath != "" {
+			ids, err := loadBlacklistIDs(blacklistPath)
+			if err != nil {
+				logger.Error("reload: load blacklist failed", "err", err)
+			} else {
+				applyBlacklistDiff(blacklist, ids, func(idHex string) {
+					closeConnectionsFor(nn, logger, idHex)
+				})
+			}
+		}
+
+		cfg, err := config.Load(*configFile)
+		if err != nil {
+			logger.Error("reload: load config failed", "err", err)
+			return
+		}
+		newMsgRate, newMsgBurst := msgRateVal, msgBurstVal
+		newConnRate, newConnBurst := connRateVal, connBurstVal
+		newLogLevel := logLevel.Load().(string)
+		applyTunables(cfg, &newMsgRate, &newMsgBurst, &newConnRate, &newConnBurst, &newLogLevel)
+		msgLimiter.SetRates(newMsgRate, newMsgBurst)
+		connLimiter.SetRates(newConnRate, newConnBurst)
+		acceptConnLimiter.SetRates(newConnRate, newConnBurst)
+		msgRateVal, msgBurstVal = newMsgRate, newMsgBurst
+		connRateVal, connBurstVal = newConnRate, newConnBurst
+		logLevel.Store(newLogLevel)
+		if lvl, err := logging.ParseLevel(newLogLevel); err == nil {
+			logger.SetLevel(lvl)
+		}
+
+		logger.Info("reload: complete")
+	}
+
+	sigHup := make(chan os.Signal, 1)
+	signal.Notify(sigHup, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-sigHup:
+				reload()
+			case <-runCtx.Done():
+				signal.Stop(sigHup)
+				return
+			}
+		}
+	}()
+
+	// SIGINT/SIGTERM cancel runCtx so an in-flight Join retry loop stops
+	// immediately instead of running its full backoff schedule, then stop
+	// the node and exit. Without this, "kill" is the only way to interrupt a
+	// node stuck retrying a failing Join, since the CLI's "exit" command
+	// (the only other path that calls cancelRun) doesn't run until after
+	// Join has already returned.
+	sigTerm := make(chan os.Signal, 1)
+	signal.Notify(sigTerm, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigTerm
+		logger.Info("received shutdown signal, exiting")
+		cancelRun()
+		nn.Stop(nil)
+		os.Exit(0)
+	}()
+
+	// acceptBackoff reuses the Join retry backoff to throttle repeatedly
+	// rejected inbound connections, so a peer hammering a closed door after
+	// a rate-limit rejection gets an increasing delay instead of re-running
+	// the full ConnectionAccepted chain on every attempt.
+	acceptBackoff := backoff.NewAcceptTracker()
+
+	// ConnectionAccepted fires in nnet's own accept loop, before any node ID
+	// is known, so it's the right place to throttle by remote IP.
+	nn.MustApplyMiddleware(node.ConnectionAccepted{func(conn net.Conn) (bool, bool) {
+		host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+		if err != nil {
+			host = conn.RemoteAddr().String()
+		}
+
+		if acceptBackoff.Blocked(host) {
+			logger.Debug("connection rejected: backing off", "addr", host)
+			return false, true
+		}
+
+		if !acceptConnLimiter.Allow(host) {
+			delay := acceptBackoff.Reject(host, errAcceptRateLimited)
+			logger.Warn("connection rate-limited", "addr", host, "retry_after", delay)
+			return false, true
+		}
+
+		acceptBackoff.Accept(host)
+		return true, true
+	}, 0})
+
 	// Set up middleware for connection handling using WillConnectToNode
 	nn.MustApplyMiddleware(node.WillConnectToNode{func(n *pbnode.Node) (bool, bool) {
 		// If node has no ID yet, allow connection to proceed
@@ -133,29 +512,107 @@ func main() {
 			return true, true
 		}
 
+		// Rate-limit connection attempts per remote IP before doing any
+		// more expensive checks, to bound handshake CPU
+		if host, _, err := net.SplitHostPort(n.Addr); err == nil {
+			if !connLimiter.Allow(host) {
+				logger.Warn("connection rate-limited", "addr", host)
+				return false, true
+			}
+		}
+
 		remoteIDHex := hex.EncodeToString(n.Id)
 
 		// Check if the node ID is blacklisted
 		if blacklist.IsBlocked(remoteIDHex) {
-			fmt.Printf("Blocked connection attempt to blacklisted node ID: %s\n", remoteIDHex)
+			logger.Warn("connection blocked: blacklisted", "remote_id", remoteIDHex)
 			return false, true // Don't connect, but continue middleware chain
 		}
 
-		fmt.Printf("Allowing connection to node with ID: %s\n", remoteIDHex)
+		logger.Info("connection allowed", "remote_id", remoteIDHex)
 		return true, true
 	}, 0})
 
-	// Also add a RemoteNodeConnected handler to log connections and show IDs
+	// Also add a RemoteNodeConnected handler to log the raw connection. The
+	// remote node's ID is typically nil at this point (per nnet's own doc
+	// comment on RemoteNodeConnected), so this is only good for logging, not
+	// for anything that needs to identify or talk to the peer.
 	nn.MustApplyMiddleware(node.RemoteNodeConnected{func(remoteNode *node.RemoteNode) bool {
+		logger.Info("remote node connected", "addr", remoteNode.Addr)
+		return true
+	}, 0})
+
+	// RemoteNodeReady fires once node info has been exchanged and the
+	// connection's message pump is running, so this is the first point a
+	// challenge/response can actually be sent and answered. If required,
+	// challenge the peer to prove it owns the private key behind its claimed
+	// ID, and mark it verified on success. This alone does not block
+	// traffic: nnet's handleMsg loop (and thus BytesReceived) starts before
+	// RemoteNodeReady runs, so BytesReceived checks verifiedPeers itself
+	// before processing anything but the handshake exchange.
+	nn.MustApplyMiddleware(node.RemoteNodeReady{func(remoteNode *node.RemoteNode) bool {
 		remoteIDHex := hex.EncodeToString(remoteNode.Id)
-		fmt.Printf("Remote node connected: %s (ID: %s)\n", remoteNode.Addr, remoteIDHex)
+		logger.Info("remote node ready", "remote_id", remoteIDHex, "addr", remoteNode.Addr)
+
+		if *requireSigned {
+			nonce := make([]byte, 32)
+			if _, err := rand.Read(nonce); err != nil {
+				logger.Error("handshake nonce generation failed", "remote_id", remoteIDHex, "err", err)
+				remoteNode.Stop(err)
+				return false
+			}
+			challenge := append([]byte(handshakePrefix), nonce...)
+			sig, _, err := nn.SendBytesRelaySync(challenge, remoteNode.Id)
+			if err != nil || !identity.Verify(remoteNode.Id, nonce, sig) {
+				logger.Warn("handshake failed, rejecting peer", "remote_id", remoteIDHex)
+				remoteNode.Stop(errors.New("signed handshake failed"))
+				return false
+			}
+			verifiedPeers.Mark(remoteIDHex)
+		}
 		return true
 	}, 0})
 
 	// Set up message handler
 	nn.MustApplyMiddleware(node.BytesReceived{func(msg, msgID, srcID []byte, remoteNode *node.RemoteNode) ([]byte, bool) {
-		senderIDHex := hex.EncodeToString(srcID)
-		fmt.Printf("\nMessage from %s: %s\n> ", senderIDHex, string(msg))
+		srcIDHex := hex.EncodeToString(srcID)
+		isHandshakeMsg := strings.HasPrefix(string(msg), handshakePrefix)
+
+		// Drop everything except the handshake exchange itself until the
+		// peer has passed it, so the RemoteNodeReady challenge actually
+		// gates traffic instead of only disconnecting after the fact (the
+		// handleMsg loop that feeds this middleware starts running before
+		// RemoteNodeReady does).
+		if *requireSigned && !isHandshakeMsg && !verifiedPeers.Verified(srcIDHex) {
+			logger.Debug("message dropped: handshake not yet verified", "src_id", srcIDHex)
+			return msg, false
+		}
+
+		if !msgLimiter.Allow(srcIDHex) {
+			logger.Debug("message dropped: rate limited", "src_id", srcIDHex)
+			return msg, false
+		}
+
+		if isHandshakeMsg {
+			nonce := []byte(strings.TrimPrefix(string(msg), handshakePrefix))
+			nn.SendBytesRelayReply(msgID, ident.Sign(nonce), srcID)
+			return msg, false
+		}
+
+		if *replayWindowEnabled {
+			if len(msg) < 8 {
+				logger.Debug("message dropped: too short for replay header", "src_id", srcIDHex)
+				return msg, false
+			}
+			seq := binary.BigEndian.Uint64(msg[:8])
+			if !replayWindow.Accept(srcIDHex, seq) {
+				logger.Warn("message dropped: replay/out-of-window", "src_id", srcIDHex, "seq", seq)
+				return msg, false
+			}
+			msg = msg[8:]
+		}
+
+		logger.Debug("message received", "src_id", srcIDHex, "msg_id", hex.EncodeToString(msgID), "msg", string(msg))
 
 		// Auto-reply with confirmation
 		nn.SendBytesRelayReply(msgID, []byte("Message received"), srcID)
@@ -176,18 +633,20 @@ func main() {
 		fmt.Println("Starting as bootstrap node")
 	} else {
 		fmt.Printf("Joining network through seed node: %s\n", *bootstrapAddr)
-		// Join the network by connecting to seed node
-		err = nn.Join(*bootstrapAddr)
+		// Join the network by connecting to seed node, retrying transient
+		// failures with exponential backoff instead of failing fatally
+		err = backoff.Retry(runCtx, func() error {
+			return nn.Join(*bootstrapAddr)
+		})
 		if err != nil {
 			log.Fatalf("Join network error: %v", err)
 		}
 	}
 
 	// Print node information
-	idStr := hex.EncodeToString(id)
 	localAddr := nn.GetLocalNode().Addr
 	localPort := nn.GetConfig().Port
-	fmt.Printf("Node ID: %s\n", idStr)
+	fmt.Printf("Node ID: %s\n", ident.Fingerprint())
 	fmt.Printf("Node listening at: %s:%d\n", localAddr, localPort)
 
 	// Command-line interface
@@ -220,6 +679,7 @@ func main() {
 
 		case "exit":
 			fmt.Println("Exiting...")
+			cancelRun()
 			nn.Stop(nil)
 			return
 
@@ -253,8 +713,12 @@ func main() {
 				break
 			}
 
-			message := strings.Join(args[2:], " ")
-			reply, senderID, err := nn.SendBytesRelaySync([]byte(message), targetID)
+			payload, err := seqPrefixed(seqCounter, *replayWindowEnabled, []byte(strings.Join(args[2:], " ")))
+			if err != nil {
+				fmt.Printf("Failed to prepare message: %v\n", err)
+				break
+			}
+			reply, senderID, err := nn.SendBytesRelaySync(payload, targetID)
 			if err != nil {
 				fmt.Printf("Failed to send message: %v\n", err)
 			} else {
@@ -268,8 +732,12 @@ func main() {
 				break
 			}
 
-			message := strings.Join(args[1:], " ")
-			_, err := nn.SendBytesBroadcastAsync([]byte(message), pbmsg.RoutingType_BROADCAST_PUSH)
+			payload, err := seqPrefixed(seqCounter, *replayWindowEnabled, []byte(strings.Join(args[1:], " ")))
+			if err != nil {
+				fmt.Printf("Failed to prepare message: %v\n", err)
+				break
+			}
+			_, err = nn.SendBytesBroadcastAsync(payload, pbmsg.RoutingType_BROADCAST_PUSH)
 			if err != nil {
 				fmt.Printf("Failed to broadcast message: %v\n", err)
 			} else {
@@ -292,6 +760,27 @@ func main() {
 
 			blacklist.RemoveID(args[1])
 
+		case "stats":
+			msgDrops := msgLimiter.Stats()
+			connDrops := connLimiter.Stats()
+			acceptDrops := acceptConnLimiter.Stats()
+			if len(msgDrops) == 0 && len(connDrops) == 0 && len(acceptDrops) == 0 {
+				fmt.Println("No rate-limit drops recorded")
+				break
+			}
+			for peerID, drops := range msgDrops {
+				fmt.Printf("  peer %s: %d messages dropped\n", peerID, drops)
+			}
+			for ip, drops := range connDrops {
+				fmt.Printf("  ip %s: %d outbound connection attempts dropped\n", ip, drops)
+			}
+			for ip, drops := range acceptDrops {
+				fmt.Printf("  ip %s: %d inbound connections dropped\n", ip, drops)
+			}
+
+		case "reload":
+			reload()
+
 		default:
 			fmt.Printf("Unknown command: %s\n", args[0])
 			fmt.Println("Type 'help' for available commands")
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/harshu4/Nnet-PoC/logging"
+)
+
+func newTestLogger(t *testing.T) *logging.Logger {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", os.DevNull, err)
+	}
+	t.Cleanup(func() { devNull.Close() })
+	return logging.New(devNull, slog.LevelError)
+}
+
+func TestApplyBlacklistDiffClosesConnectionsForNewlyBlockedOnly(t *testing.T) {
+	blacklist := NewBlackList(newTestLogger(t))
+	blacklist.AddID("already-blocked")
+
+	var closed []string
+	closeConn := func(idHex string) { closed = append(closed, idHex) }
+
+	// "already-blocked" stays blocked (no-op), "new-block" is newly added and
+	// must trigger closeConn; "already-blocked" must NOT trigger it since it
+	// isn't newly blocked.
+	applyBlacklistDiff(blacklist, []string{"already-blocked", "new-block"}, closeConn)
+
+	if len(closed) != 1 || closed[0] != "new-block" {
+		t.Fatalf("expected closeConn called only for the newly blocked id, got %v", closed)
+	}
+	if !blacklist.IsBlocked("already-blocked") || !blacklist.IsBlocked("new-block") {
+		t.Fatal("both ids should be blocked after the diff")
+	}
+}
+
+func TestApplyBlacklistDiffDoesNotCloseConnectionsOnUnblock(t *testing.T) {
+	blacklist := NewBlackList(newTestLogger(t))
+	blacklist.AddID("unblock-me")
+
+	var closed []string
+	closeConn := func(idHex string) { closed = append(closed, idHex) }
+
+	// Unblocking should free the peer to reconnect on its own, not tear down
+	// a connection the operator just chose to allow.
+	applyBlacklistDiff(blacklist, nil, closeConn)
+
+	if len(closed) != 0 {
+		t.Fatalf("unblocking must not close any connection, got %v", closed)
+	}
+	if blacklist.IsBlocked("unblock-me") {
+		t.Fatal("id should no longer be blocked")
+	}
+}
+
+func TestApplyBlacklistDiffNoChangeCallsNothing(t *testing.T) {
+	blacklist := NewBlackList(newTestLogger(t))
+	blacklist.AddID("stays-blocked")
+
+	closeConn := func(idHex string) { t.Fatalf("closeConn should not be called, got %q", idHex) }
+
+	applyBlacklistDiff(blacklist, []string{"stays-blocked"}, closeConn)
+
+	if !blacklist.IsBlocked("stays-blocked") {
+		t.Fatal("id should remain blocked")
+	}
+}
@@ -0,0 +1,126 @@
+// Package ratelimiter implements per-key token-bucket rate limiting, used to
+// bound the CPU a misbehaving or compromised peer can spend on this node
+// (either by flooding messages or by repeatedly attempting to connect).
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket is a single token bucket plus its drop counter and last-seen time,
+// the latter used to garbage-collect idle keys.
+type bucket struct {
+	tokens   float64
+	lastFill time.Time
+	lastSeen time.Time
+	drops    uint64
+}
+
+// Limiter is a set of independent token buckets keyed by an arbitrary
+// string (source node ID, remote IP, ...). Each bucket holds up to burst
+// tokens and refills at rate tokens/sec.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New creates a Limiter where each key gets its own bucket refilling at
+// rate tokens/sec up to a maximum of burst tokens.
+func New(rate, burst float64) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow reports whether a single event for key may proceed, consuming one
+// token if so. Events arriving with no tokens available are dropped and
+// counted against key.
+func (l *Limiter) Allow(key string) bool {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, lastFill: now}
+		l.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastFill).Seconds()
+	b.tokens += elapsed * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.lastFill = now
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		b.drops++
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// SetRates updates the refill rate and burst size applied going forward.
+// Existing buckets keep whatever tokens they've already accumulated, capped
+// at the new burst on their next Allow.
+func (l *Limiter) SetRates(rate, burst float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = burst
+}
+
+// Stats returns a snapshot of drop counts per key.
+func (l *Limiter) Stats() map[string]uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]uint64, len(l.buckets))
+	for key, b := range l.buckets {
+		if b.drops > 0 {
+			stats[key] = b.drops
+		}
+	}
+	return stats
+}
+
+// GC removes buckets that have not been touched in at least maxIdle,
+// keeping the map from growing without bound as peers come and go.
+func (l *Limiter) GC(maxIdle time.Duration) {
+	cutoff := time.Now().Add(-maxIdle)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RunGC periodically calls GC until ctx is cancelled. It is meant to be
+// started in its own goroutine.
+func (l *Limiter) RunGC(ctx context.Context, interval, maxIdle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.GC(maxIdle)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
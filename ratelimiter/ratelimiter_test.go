@@ -0,0 +1,133 @@
+package ratelimiter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAllowConsumesBurstThenDrops(t *testing.T) {
+	l := New(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow("key") {
+			t.Fatalf("event %d should have been allowed within the burst", i)
+		}
+	}
+	if l.Allow("key") {
+		t.Fatal("event beyond the burst should have been dropped")
+	}
+}
+
+func TestAllowRefillsOverTime(t *testing.T) {
+	b := &bucket{tokens: 0, lastFill: time.Now().Add(-time.Second)}
+	l := &Limiter{rate: 10, burst: 10, buckets: map[string]*bucket{"key": b}}
+
+	if !l.Allow("key") {
+		t.Fatal("a bucket that has been idle for one second at 10 tokens/sec should have refilled at least one token")
+	}
+}
+
+func TestAllowRefillCapsAtBurst(t *testing.T) {
+	b := &bucket{tokens: 0, lastFill: time.Now().Add(-time.Hour)}
+	l := &Limiter{rate: 10, burst: 5, buckets: map[string]*bucket{"key": b}}
+
+	l.Allow("key")
+
+	l.mu.Lock()
+	tokens := l.buckets["key"].tokens
+	l.mu.Unlock()
+
+	if tokens > float64(l.burst) {
+		t.Fatalf("tokens must be capped at burst (%v), got %v", l.burst, tokens)
+	}
+}
+
+func TestAllowKeysAreIndependent(t *testing.T) {
+	l := New(1, 1)
+
+	if !l.Allow("a") {
+		t.Fatal("first event for key a should be allowed")
+	}
+	if !l.Allow("b") {
+		t.Fatal("key b must have its own bucket, unaffected by key a's consumption")
+	}
+}
+
+func TestStatsOnlyReportsKeysWithDrops(t *testing.T) {
+	l := New(0, 1)
+
+	l.Allow("dropped")   // consumes the only token
+	l.Allow("dropped")   // dropped, counted
+	l.Allow("untouched") // consumes its only token, never dropped
+
+	stats := l.Stats()
+	if stats["dropped"] != 1 {
+		t.Fatalf("expected 1 drop for 'dropped', got %d", stats["dropped"])
+	}
+	if _, ok := stats["untouched"]; ok {
+		t.Fatal("a key with zero drops should not appear in Stats")
+	}
+}
+
+func TestGCRemovesOnlyIdleKeys(t *testing.T) {
+	l := New(1, 1)
+	l.Allow("idle")
+	l.Allow("fresh")
+
+	l.mu.Lock()
+	l.buckets["idle"].lastSeen = time.Now().Add(-time.Hour)
+	l.mu.Unlock()
+
+	l.GC(time.Minute)
+
+	l.mu.Lock()
+	_, idleStillThere := l.buckets["idle"]
+	_, freshStillThere := l.buckets["fresh"]
+	l.mu.Unlock()
+
+	if idleStillThere {
+		t.Fatal("GC should have removed the idle key")
+	}
+	if !freshStillThere {
+		t.Fatal("GC should not have removed a recently touched key")
+	}
+}
+
+func TestRunGCStopsOnContextCancel(t *testing.T) {
+	l := New(1, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		l.RunGC(ctx, time.Millisecond, time.Minute)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunGC did not return after its context was cancelled")
+	}
+}
+
+func TestConcurrentAllowAndGC(t *testing.T) {
+	l := New(1000, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l.Allow("key")
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		l.GC(time.Hour)
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWindowAcceptsFirstAndIncreasingSeq(t *testing.T) {
+	w := NewWindow()
+
+	if !w.Accept("peer", 0) {
+		t.Fatal("first sequence number must be accepted")
+	}
+	if !w.Accept("peer", 1) {
+		t.Fatal("strictly increasing sequence number must be accepted")
+	}
+	if !w.Accept("peer", 100) {
+		t.Fatal("jump ahead within uint64 range must be accepted")
+	}
+}
+
+func TestWindowRejectsDuplicate(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peer", 5)
+	if w.Accept("peer", 5) {
+		t.Fatal("resending the same sequence number must be rejected")
+	}
+}
+
+func TestWindowAcceptsOutOfOrderWithinWindow(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peer", 10)
+	if !w.Accept("peer", 7) {
+		t.Fatal("a reordered but still-in-window sequence number must be accepted")
+	}
+	if w.Accept("peer", 7) {
+		t.Fatal("replaying that same reordered sequence number must be rejected")
+	}
+}
+
+func TestWindowRejectsTooOld(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peer", windowBits)
+	if w.Accept("peer", 0) {
+		t.Fatal("a sequence number exactly windowBits behind head must be rejected as too old")
+	}
+}
+
+func TestWindowAdvanceWithinWindowShiftsBitmap(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peer", 0)
+	w.Accept("peer", windowBits-1) // advance < windowBits, exercises shift()
+
+	if w.Accept("peer", 0) {
+		t.Fatal("bit for seq 0 should have shifted to the trailing edge, not been dropped or cleared")
+	}
+	if !w.Accept("peer", 1) {
+		t.Fatal("seq 1 was never seen and should still be acceptable after the shift")
+	}
+}
+
+func TestWindowAdvanceBeyondWindowResetsBitmap(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peer", 0)
+	w.Accept("peer", 50)
+	// advance == windowBits hits the ">=" boundary in peerWindow.accept and
+	// must reset the whole bitmap rather than shift-with-overflow.
+	if !w.Accept("peer", 50+windowBits) {
+		t.Fatal("advancing by exactly windowBits must be accepted")
+	}
+	if !w.Accept("peer", 50+windowBits-1) {
+		t.Fatal("after the bitmap reset, a sequence number inside the new window must be treated as unseen")
+	}
+}
+
+func TestWindowTracksPeersIndependently(t *testing.T) {
+	w := NewWindow()
+
+	w.Accept("peerA", 5)
+	if !w.Accept("peerB", 5) {
+		t.Fatal("sequence numbers from different peers must not collide")
+	}
+}
+
+func TestWindowConcurrentAccess(t *testing.T) {
+	w := NewWindow()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(seq uint64) {
+			defer wg.Done()
+			w.Accept("peer", seq)
+		}(uint64(i))
+	}
+	wg.Wait()
+}